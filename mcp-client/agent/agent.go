@@ -0,0 +1,164 @@
+// Package agent drives the tool-call loop: send the conversation and the
+// available tools to a ChatCompletionProvider, execute whatever tool
+// calls come back, feed the results back in, and repeat until the model
+// answers in plain text. It replaces the old TOOL: name {json} string
+// parsing with native function-calling on every provider.
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+)
+
+// maxIterations bounds a single Run so a misbehaving model can't loop
+// forever calling tools without ever producing a final answer.
+const maxIterations = 10
+
+// sideEffectfulTools lists MCP tools that mutate Jenkins state. Calls to
+// these are gated behind Agent.Confirm.
+var sideEffectfulTools = map[string]bool{
+	"trigger_job": true,
+}
+
+// IsSideEffectful reports whether a tool call can change Jenkins state
+// (as opposed to a read-only query like get_build_status).
+func IsSideEffectful(toolName string) bool {
+	return sideEffectfulTools[toolName]
+}
+
+// ToolExecutor runs a single tool call (normally by forwarding it to the
+// MCP server) and returns its result.
+type ToolExecutor func(ctx context.Context, call api.ToolCall) (api.CallResult, error)
+
+// ConfirmFunc asks the user whether a side-effectful call should proceed.
+type ConfirmFunc func(call api.ToolCall) bool
+
+// Agent pairs a system prompt with the subset of tools it's allowed to
+// call and the provider it talks to. Named agents (devops, readonly,
+// troubleshooter) are just Agents built with different AllowedTools.
+type Agent struct {
+	Name         string
+	SystemPrompt string
+	AllowedTools map[string]bool // nil = all tools allowed
+	Provider     api.ChatCompletionProvider
+	Confirm      bool
+	ConfirmFunc  ConfirmFunc
+}
+
+// FilterTools restricts tools to the ones this agent is allowed to call.
+func (a *Agent) FilterTools(tools []api.ToolSpec) []api.ToolSpec {
+	if a.AllowedTools == nil {
+		return tools
+	}
+	filtered := make([]api.ToolSpec, 0, len(tools))
+	for _, t := range tools {
+		if a.AllowedTools[t.Name] {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// Run executes the tool-call loop: ask the provider for a completion,
+// execute any tool calls it requests via exec, append the results, and
+// ask again, until the model replies without tool calls or maxIterations
+// is hit. It returns the full updated history.
+func (a *Agent) Run(ctx context.Context, history []api.Message, tools []api.ToolSpec, exec ToolExecutor) ([]api.Message, error) {
+	allowed := a.FilterTools(tools)
+
+	for i := 0; i < maxIterations; i++ {
+		reply, err := a.Provider.CreateChatCompletion(ctx, history, allowed)
+		if err != nil {
+			return history, fmt.Errorf("agent %s: chat completion: %w", a.Name, err)
+		}
+		history = append(history, reply)
+
+		if len(reply.ToolCalls) == 0 {
+			return history, nil
+		}
+
+		for _, call := range reply.ToolCalls {
+			if a.AllowedTools != nil && !a.AllowedTools[call.Name] {
+				history = append(history, api.Message{
+					Role:       "tool",
+					Content:    fmt.Sprintf("tool %q is not permitted for agent %q", call.Name, a.Name),
+					ToolCallID: call.ID,
+				})
+				continue
+			}
+
+			if a.Confirm && IsSideEffectful(call.Name) && a.ConfirmFunc != nil && !a.ConfirmFunc(call) {
+				history = append(history, api.Message{
+					Role:       "tool",
+					Content:    fmt.Sprintf("call to %q was declined by the user", call.Name),
+					ToolCallID: call.ID,
+				})
+				continue
+			}
+
+			result, err := exec(ctx, call)
+			if err != nil {
+				result = api.CallResult{ToolCallID: call.ID, Content: err.Error(), IsError: true}
+			}
+			history = append(history, api.Message{
+				Role:       "tool",
+				Content:    result.Content,
+				ToolCallID: result.ToolCallID,
+			})
+		}
+	}
+
+	return history, fmt.Errorf("agent %s: exceeded %d tool-call iterations without a final answer", a.Name, maxIterations)
+}
+
+// Named builds one of the predefined agents (devops, readonly,
+// troubleshooter) bound to provider. An unknown name returns an error so
+// callers can report a clear --agent flag mistake.
+func Named(name string, provider api.ChatCompletionProvider, confirm bool, confirmFn ConfirmFunc) (*Agent, error) {
+	switch name {
+	case "devops":
+		return &Agent{
+			Name:         "devops",
+			SystemPrompt: "You are a DevOps assistant with full access to Jenkins. You can trigger builds, check status, and inspect logs.",
+			AllowedTools: nil, // every tool
+			Provider:     provider,
+			Confirm:      confirm,
+			ConfirmFunc:  confirmFn,
+		}, nil
+	case "readonly":
+		return &Agent{
+			Name:         "readonly",
+			SystemPrompt: "You are a read-only Jenkins assistant. You can check build status, list jobs/nodes, and read logs, but you must never trigger a build.",
+			AllowedTools: map[string]bool{
+				"get_build_status":   true,
+				"get_console_log":    true,
+				"stream_console_log": true,
+				"list_jobs":          true,
+				"list_nodes":         true,
+				"get_queue":          true,
+				"get_job_metrics":    true,
+			},
+			Provider:    provider,
+			Confirm:     confirm,
+			ConfirmFunc: confirmFn,
+		}, nil
+	case "troubleshooter":
+		return &Agent{
+			Name:         "troubleshooter",
+			SystemPrompt: "You are a Jenkins troubleshooter. Given a failing job, fetch its status and console log, then explain the root cause and a fix. You must never trigger a build.",
+			AllowedTools: map[string]bool{
+				"get_build_status":   true,
+				"get_console_log":    true,
+				"stream_console_log": true,
+				"get_job_metrics":    true,
+			},
+			Provider:    provider,
+			Confirm:     confirm,
+			ConfirmFunc: confirmFn,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown agent %q (want devops, readonly, or troubleshooter)", name)
+	}
+}
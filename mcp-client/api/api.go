@@ -0,0 +1,55 @@
+// Package api defines the provider-agnostic chat/tool-call types shared
+// by the agent loop and every ChatCompletionProvider backend (OpenAI,
+// Anthropic, Ollama, Google, ...).
+package api
+
+import "context"
+
+// Message is one turn in a conversation. Role is "system", "user",
+// "assistant", or "tool". ToolCalls is set on assistant messages that
+// invoke tools; ToolCallID is set on the tool message replying to one.
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolSpec describes one MCP tool in provider-neutral form, translated
+// from the MCP server's ListTools response. Each provider implementation
+// converts a []ToolSpec into its own function-calling schema.
+type ToolSpec struct {
+	Name        string
+	Description string
+	InputSchema map[string]any
+}
+
+// ToolCall is a model-requested invocation of a tool, normalized from
+// whatever shape the underlying provider's API returns it in.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]any
+}
+
+// CallResult is the outcome of executing a ToolCall, fed back to the
+// model as a "tool" role message keyed by ToolCallID.
+type CallResult struct {
+	ToolCallID string
+	Content    string
+	IsError    bool
+}
+
+// ChatCompletionProvider is the interface every LLM backend implements so
+// the agent loop can drive multi-step tool-call conversations without
+// knowing which provider it's talking to.
+type ChatCompletionProvider interface {
+	// Name identifies the provider, e.g. "openai", "anthropic".
+	Name() string
+
+	// CreateChatCompletion sends the conversation so far plus the set of
+	// tools currently available, and returns the model's reply. The
+	// reply is either plain text (ToolCalls empty) or one or more tool
+	// calls for the agent loop to execute.
+	CreateChatCompletion(ctx context.Context, messages []Message, tools []ToolSpec) (Message, error)
+}
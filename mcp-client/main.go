@@ -3,286 +3,453 @@ package main
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
-	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/agent"
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/providers"
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/store"
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/tui"
 )
 
-// Message structure for conversation
-type Message struct {
-	Role    string `json:"role"`    // "user" or "assistant"
-	Content string `json:"content"` // text content
+// session bundles everything a subcommand needs to drive one agent turn:
+// a connected MCP client, its tool list, and the named agent to run.
+type session struct {
+	mcpClient *client.Client
+	tools     []api.ToolSpec
+	agent     *agent.Agent
 }
 
 func main() {
-	// Get OpenAI API key from environment
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		log.Fatal("OPENAI_API_KEY environment variable not set")
+	agentName := flag.String("agent", "devops", "named agent to run: devops, readonly, or troubleshooter")
+	confirm := flag.Bool("confirm", false, "prompt before executing side-effectful tool calls (e.g. trigger_job)")
+	model := flag.String("model", "", "model name to pass to the LLM provider (provider-specific default if empty)")
+	dbPath := flag.String("db", defaultDBPath(), "path to the conversation store's SQLite database")
+	flag.Parse()
+
+	st, err := store.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("failed to open conversation store: %v", err)
+	}
+	defer st.Close()
+
+	args := flag.Args()
+	cmd := "chat"
+	if len(args) > 0 {
+		cmd = args[0]
+		args = args[1:]
+	}
+
+	switch cmd {
+	case "ls":
+		runLs(st)
+	case "view":
+		runView(st, args)
+	case "rm":
+		runRm(st, args)
+	case "tui":
+		runTUI(st, args)
+	case "new":
+		runNew(st, *agentName, *confirm, *model, args)
+	case "reply":
+		runReply(st, *agentName, *confirm, *model, args)
+	case "chat":
+		runChat(st, *agentName, *confirm, *model)
+	default:
+		log.Fatalf("unknown subcommand %q (want chat, new, reply, view, ls, rm, or tui)", cmd)
+	}
+}
+
+func defaultDBPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "jenkins-llm-bridge.db"
+	}
+	return filepath.Join(dir, "jenkins-llm-bridge", "conversations.db")
+}
+
+// newSession connects to the MCP server, lists its tools, and builds the
+// requested named agent. Every subcommand that talks to the LLM goes
+// through this.
+func newSession(agentName string, confirm bool, model string) (*session, error) {
+	provider, err := providers.New(os.Getenv("LLM_PROVIDER"), model)
+	if err != nil {
+		return nil, err
 	}
 
-	// MCP server SSE URL
 	mcpURL := "http://localhost:8081/sse"
 	if u := os.Getenv("MCP_SERVER_URL"); u != "" {
 		mcpURL = u
 	}
 
-	// Connect to MCP server
 	mcpClient, err := client.NewSSEMCPClient(mcpURL)
 	if err != nil {
-		log.Fatalf("failed to connect to MCP server: %v", err)
+		return nil, fmt.Errorf("failed to connect to MCP server: %w", err)
 	}
-	defer mcpClient.Close()
 
 	ctx := context.Background()
-
-	// Start client connection before Initialize
 	ready := make(chan error, 1)
-
-	go func() {
-		// Start blocks until error or close
-		ready <- mcpClient.Start(ctx)
-	}()
-
-	// Wait for transport to be ready
+	go func() { ready <- mcpClient.Start(ctx) }()
 	if err := <-ready; err != nil {
-		log.Fatalf("mcp connection start failed: %v", err)
+		return nil, fmt.Errorf("mcp connection start failed: %w", err)
 	}
 
-	// Initialize MCP session
-	initResp, err := mcpClient.Initialize(ctx, mcp.InitializeRequest{
+	if _, err := mcpClient.Initialize(ctx, mcp.InitializeRequest{
 		Params: mcp.InitializeParams{
 			ProtocolVersion: "2024-11-05",
-			ClientInfo: mcp.Implementation{
-				Name:    "jenkins-llm-bridge",
-				Version: "0.1",
-			},
+			ClientInfo:      mcp.Implementation{Name: "jenkins-llm-bridge", Version: "0.1"},
 		},
-	})
-	if err != nil {
-		log.Fatalf("failed to initialize MCP client: %v", err)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to initialize MCP client: %w", err)
 	}
 
-	fmt.Printf("MCP initialized. Server: %+v\n", initResp.ServerInfo)
+	tools, err := fetchToolSpecs(ctx, mcpClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MCP tools: %w", err)
+	}
 
-	// OpenAI client
-	oa := openai.NewClient(apiKey)
+	a, err := agent.Named(agentName, provider, confirm, confirmOnStdin)
+	if err != nil {
+		return nil, err
+	}
 
-	// Conversation history
-	history := []Message{
-		{
-			Role: "system",
-			Content: `You are a DevOps assistant.
-				- "run", "start", "trigger job" → TOOL: trigger_job {"job_name": "<name>"}
-				- "status", "check build" → TOOL: get_build_status {"job_name": "<name>", "build_number": <number>}
-				- "logs", "console output" → TOOL: get_console_log {"job_name": "<name>", "build_number": <number>}
-				- "troubleshoot", "analyze logs", "debug", "why did it fail" → TOOL: analyze_logs {"job_name": "<name>", "build_number": <number>}
+	return &session{mcpClient: mcpClient, tools: tools, agent: a}, nil
+}
 
-				Never answer in natural language for these cases.`,
-		},
+// runChat is the original interactive REPL, now persisting every turn of
+// the session as one conversation so it can be resumed with `reply`.
+func runChat(st *store.Store, agentName string, confirm bool, model string) {
+	sess, err := newSession(agentName, confirm, model)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
+	defer sess.mcpClient.Close()
+
+	ctx := context.Background()
+	exec := mcpToolExecutor(sess.mcpClient)
 
-	fmt.Println("Jenkins LLM Bridge started. Type your prompts:")
+	fmt.Printf("Jenkins LLM Bridge started (agent=%s, confirm=%v). Type your prompts:\n", sess.agent.Name, confirm)
+
+	var (
+		conversationID int64
+		leafID         int64
+		history        []api.Message
+	)
 
-	// REPL loop
 	scanner := bufio.NewScanner(os.Stdin)
 	for {
 		fmt.Print("> ")
-		var input string
 		if !scanner.Scan() {
 			break
 		}
-		input = scanner.Text()
+		input := scanner.Text()
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
 
-		if err != nil {
-			if err.Error() == "unexpected newline" {
-				continue
+		if conversationID == 0 {
+			history = []api.Message{{Role: "system", Content: sess.agent.SystemPrompt}}
+			conversationID, leafID, err = st.NewConversation(conversationTitle(input), history[0])
+			if err != nil {
+				log.Printf("failed to persist conversation: %v", err)
 			}
-			log.Printf("input error: %v", err)
-			continue
 		}
 
-		// Append user message
-		history = append(history, Message{
-			Role:    "user",
-			Content: input,
-		})
+		history = append(history, api.Message{Role: "user", Content: input})
 
-		// Send prompt to OpenAI
-		ctx := context.Background()
-		resp, err := oa.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-			Model:       "gpt-4",
-			Messages:    convertMessages(history),
-			Temperature: 0.2,
-		})
+		updated, err := sess.agent.Run(ctx, history, sess.tools, exec)
 		if err != nil {
-			log.Printf("OpenAI error: %v", err)
+			log.Printf("agent error: %v", err)
 			continue
 		}
 
-		llmReply := resp.Choices[0].Message.Content
-		fmt.Printf("LLM: %s\n", llmReply)
-
-		// Check if LLM wants to call a tool
-		if toolCall := parseToolCall(llmReply); toolCall != nil {
-			fmt.Printf("→ Detected MCP tool call: %+v\n", toolCall)
-
-			if toolCall.Name == "analyze_logs" {
-				// Special call: fetch logs first, then analyze with OpenAI
-				jobName, _ := toolCall.Params["job_name"].(string)
-				buildNum := int(toolCall.Params["build_number"].(float64))
-
-				// Step 1: get logs from MCP
-				logReq := mcp.CallToolRequest{
-					Params: mcp.CallToolParams{
-						Name: "get_console_log",
-						Arguments: map[string]any{
-							"job_name":     jobName,
-							"build_number": buildNum,
-						},
-					},
-				}
-				logResp, err := mcpClient.CallTool(ctx, logReq)
-				if err != nil {
-					fmt.Printf("MCP log fetch error: %v\n", err)
-					continue
-				}
-
-				logContent := logResp.Content
-				// Convert MCP tool output (array of Content) into plain text
-				toolText := extractTextFromContent(logContent)
-				fmt.Println("→ Jenkins logs fetched, sending to OpenAI...")
-
-				// Step 2: send logs to OpenAI for troubleshooting
-				analysis, err := oa.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-					Model: "gpt-4o-mini",
-					Messages: []openai.ChatCompletionMessage{
-						{Role: "system", Content: "You are a DevOps expert. Analyze Jenkins logs and explain errors, causes, and fixes."},
-						{Role: "user", Content: toolText},
-					},
-					MaxTokens: 500,
-				})
-				if err != nil {
-					fmt.Printf("OpenAI log analysis error: %v\n", err)
-					continue
-				}
-
-				result := analysis.Choices[0].Message.Content
-				fmt.Printf("🔎 Analysis: %s\n", result)
-
-				// Add back into history
-				history = append(history, Message{
-					Role:    "assistant",
-					Content: fmt.Sprintf("[Log analysis]: %s", result),
-				})
+		newTurns := updated[len(history):]
+		if leafID != 0 {
+			if id, err := st.AppendMessages(conversationID, leafID, append([]api.Message{history[len(history)-1]}, newTurns...)); err != nil {
+				log.Printf("failed to persist turn: %v", err)
 			} else {
-				// Normal MCP tool call
-				req := mcp.CallToolRequest{
-					Params: mcp.CallToolParams{
-						Name:      toolCall.Name,
-						Arguments: toolCall.Params,
-					},
-				}
-				toolResp, err := mcpClient.CallTool(ctx, req)
-				if err != nil {
-					fmt.Printf("MCP call error: %v\n", err)
-					continue
-				}
-				fmt.Printf("→ Tool result: %+v\n", toolResp)
-
-				history = append(history, Message{
-					Role:    "assistant",
-					Content: fmt.Sprintf("[Tool output]: %v", toolResp.Result),
-				})
+				leafID = id
 			}
-		} else {
-			history = append(history, Message{Role: "assistant", Content: llmReply})
 		}
+
+		history = updated
+		printLastReply(history)
+	}
+
+	if conversationID != 0 {
+		fmt.Printf("conversation saved as id %d (resume with: reply %d \"...\")\n", conversationID, conversationID)
 	}
 }
 
-// extractTextFromContent flattens []mcp.Content into a readable string
-func extractTextFromContent(contents []mcp.Content) string {
-	var sb strings.Builder
-	for _, c := range contents {
-		switch v := c.(type) {
-		case *mcp.TextContent:
-			sb.WriteString(v.Text)
-			sb.WriteString("\n")
-		default:
-			// fallback: dump raw JSON if it's not text
-			b, _ := json.Marshal(v)
-			sb.Write(b)
-			sb.WriteString("\n")
+// runNew starts a brand new persisted conversation with a single prompt.
+func runNew(st *store.Store, agentName string, confirm bool, model string, args []string) {
+	if len(args) < 1 {
+		log.Fatal("usage: new <prompt>")
+	}
+	prompt := strings.Join(args, " ")
+
+	sess, err := newSession(agentName, confirm, model)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer sess.mcpClient.Close()
+
+	ctx := context.Background()
+	history := []api.Message{
+		{Role: "system", Content: sess.agent.SystemPrompt},
+		{Role: "user", Content: prompt},
+	}
+
+	updated, err := sess.agent.Run(ctx, history, sess.tools, mcpToolExecutor(sess.mcpClient))
+	if err != nil {
+		log.Fatalf("agent error: %v", err)
+	}
+
+	conversationID, rootID, err := st.NewConversation(conversationTitle(prompt), history[0])
+	if err != nil {
+		log.Fatalf("failed to persist conversation: %v", err)
+	}
+	leafID, err := st.AppendMessages(conversationID, rootID, updated[1:])
+	if err != nil {
+		log.Fatalf("failed to persist turn: %v", err)
+	}
+
+	printLastReply(updated)
+	fmt.Printf("saved as conversation %d (leaf message %d)\n", conversationID, leafID)
+}
+
+// runReply continues a stored conversation. By default it replies to the
+// latest leaf; passing --parent <message_id> as the first arg instead
+// edits a past message and starts a new branch from there, leaving the
+// original thread untouched.
+func runReply(st *store.Store, agentName string, confirm bool, model string, args []string) {
+	fs := flag.NewFlagSet("reply", flag.ExitOnError)
+	parentID := fs.Int64("parent", 0, "message ID to branch from instead of the latest leaf")
+	fs.Parse(args)
+	rest := fs.Args()
+	if len(rest) < 2 {
+		log.Fatal("usage: reply [--parent <message_id>] <conversation_id> <prompt>")
+	}
+
+	conversationID, err := strconv.ParseInt(rest[0], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid conversation_id: %v", err)
+	}
+	prompt := strings.Join(rest[1:], " ")
+
+	parent := *parentID
+	if parent == 0 {
+		parent, err = st.LatestLeaf(conversationID)
+		if err != nil {
+			log.Fatalf("failed to find latest message: %v", err)
 		}
 	}
-	return strings.TrimSpace(sb.String())
+
+	thread, err := st.Thread(parent)
+	if err != nil {
+		log.Fatalf("failed to load conversation thread: %v", err)
+	}
+
+	history := make([]api.Message, 0, len(thread)+1)
+	for _, m := range thread {
+		history = append(history, m.Message)
+	}
+	history = append(history, api.Message{Role: "user", Content: prompt})
+
+	sess, err := newSession(agentName, confirm, model)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer sess.mcpClient.Close()
+
+	updated, err := sess.agent.Run(context.Background(), history, sess.tools, mcpToolExecutor(sess.mcpClient))
+	if err != nil {
+		log.Fatalf("agent error: %v", err)
+	}
+
+	newTurns := updated[len(thread):]
+	leafID, err := st.AppendMessages(conversationID, parent, newTurns)
+	if err != nil {
+		log.Fatalf("failed to persist turn: %v", err)
+	}
+
+	printLastReply(updated)
+	fmt.Printf("saved (leaf message %d)\n", leafID)
 }
 
-// convertMessages maps history to OpenAI chat messages
-func convertMessages(history []Message) []openai.ChatCompletionMessage {
-	m := []openai.ChatCompletionMessage{}
-	for _, msg := range history {
-		role := msg.Role // keep "system", "user", "assistant"
-		m = append(m, openai.ChatCompletionMessage{
-			Role:    role,
-			Content: msg.Content,
-		})
+func runView(st *store.Store, args []string) {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	leaf := fs.Int64("leaf", 0, "message ID to view the branch ending at, instead of the latest leaf")
+	fs.Parse(args)
+	rest := fs.Args()
+	if len(rest) < 1 {
+		log.Fatal("usage: view [--leaf <message_id>] <conversation_id>")
+	}
+
+	conversationID, err := strconv.ParseInt(rest[0], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid conversation_id: %v", err)
+	}
+
+	id := *leaf
+	if id == 0 {
+		id, err = st.LatestLeaf(conversationID)
+		if err != nil {
+			log.Fatalf("failed to find latest message: %v", err)
+		}
+	}
+
+	thread, err := st.Thread(id)
+	if err != nil {
+		log.Fatalf("failed to load conversation thread: %v", err)
+	}
+	for _, m := range thread {
+		if m.Role == "system" {
+			continue
+		}
+		fmt.Printf("[%d] %s: %s\n", m.ID, m.Role, m.Content)
 	}
-	return m
 }
 
-// ToolCall struct
-type ToolCall struct {
-	Name   string
-	Params map[string]any
+func runLs(st *store.Store) {
+	conversations, err := st.ListConversations()
+	if err != nil {
+		log.Fatalf("failed to list conversations: %v", err)
+	}
+	for _, c := range conversations {
+		fmt.Printf("%d\t%s\t%s\n", c.ID, c.CreatedAt.Format("2006-01-02 15:04:05"), c.Title)
+	}
 }
 
-// parseToolCall parses LLM output for a simple "call tool" syntax
-// Example expected format: TOOL: trigger_job {"job_name":"demo-job"}
-func parseToolCall(reply string) *ToolCall {
-	reply = strings.TrimSpace(reply)
-	if !strings.HasPrefix(reply, "TOOL:") {
-		return nil
+func runRm(st *store.Store, args []string) {
+	if len(args) < 1 {
+		log.Fatal("usage: rm <conversation_id>")
+	}
+	conversationID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid conversation_id: %v", err)
+	}
+	if err := st.RemoveConversation(conversationID); err != nil {
+		log.Fatalf("failed to remove conversation: %v", err)
 	}
+}
 
-	// Split into tool name and the rest
-	raw := strings.TrimSpace(reply[len("TOOL:"):])
-	parts := strings.SplitN(raw, " ", 2)
-	if len(parts) < 2 {
-		return nil
+// runTUI opens the bubbletea branch browser for a conversation; the
+// message the user picks is printed so it can be piped into `view` or
+// `reply --parent`.
+func runTUI(st *store.Store, args []string) {
+	if len(args) < 1 {
+		log.Fatal("usage: tui <conversation_id>")
+	}
+	conversationID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		log.Fatalf("invalid conversation_id: %v", err)
 	}
 
-	name := strings.TrimSpace(parts[0])
-	rawParams := strings.TrimSpace(parts[1])
+	messages, err := st.Tree(conversationID)
+	if err != nil {
+		log.Fatalf("failed to load conversation: %v", err)
+	}
+
+	selected, ok, err := tui.Browse(messages)
+	if err != nil {
+		log.Fatalf("tui error: %v", err)
+	}
+	if ok {
+		fmt.Printf("selected message %d\n", selected)
+	}
+}
 
-	// Extract only the {...} part for JSON safety
-	start := strings.Index(rawParams, "{")
-	end := strings.LastIndex(rawParams, "}")
-	if start == -1 || end == -1 || end <= start {
-		log.Printf("invalid tool params, no JSON object found: %s", rawParams)
-		return nil
+func conversationTitle(prompt string) string {
+	if len(prompt) > 60 {
+		return prompt[:60]
 	}
-	jsonStr := rawParams[start : end+1]
+	return prompt
+}
 
-	// Fix common LLM issues: True/False/None → true/false/null
-	jsonStr = strings.ReplaceAll(jsonStr, "True", "true")
-	jsonStr = strings.ReplaceAll(jsonStr, "False", "false")
-	jsonStr = strings.ReplaceAll(jsonStr, "None", "null")
+func printLastReply(history []api.Message) {
+	last := history[len(history)-1]
+	if last.Role == "assistant" {
+		fmt.Printf("LLM: %s\n", last.Content)
+	}
+}
 
-	// Parse JSON
-	var params map[string]any
-	err := json.Unmarshal([]byte(jsonStr), &params)
+// fetchToolSpecs translates the MCP server's ListTools response into the
+// provider-agnostic api.ToolSpec shape the agent loop works with.
+func fetchToolSpecs(ctx context.Context, mcpClient *client.Client) ([]api.ToolSpec, error) {
+	listResp, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{})
 	if err != nil {
-		log.Printf("failed to parse tool params JSON: %v\nraw JSON: %s", err, jsonStr)
-		return nil
+		return nil, err
 	}
 
-	return &ToolCall{Name: name, Params: params}
+	specs := make([]api.ToolSpec, 0, len(listResp.Tools))
+	for _, t := range listResp.Tools {
+		specs = append(specs, api.ToolSpec{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: schemaToMap(t.InputSchema),
+		})
+	}
+	return specs, nil
+}
+
+func schemaToMap(schema mcp.ToolInputSchema) map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": schema.Properties,
+		"required":   schema.Required,
+	}
+}
+
+// mcpToolExecutor adapts the MCP client's CallTool into agent.ToolExecutor.
+func mcpToolExecutor(mcpClient *client.Client) agent.ToolExecutor {
+	return func(ctx context.Context, call api.ToolCall) (api.CallResult, error) {
+		resp, err := mcpClient.CallTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      call.Name,
+				Arguments: call.Arguments,
+			},
+		})
+		if err != nil {
+			return api.CallResult{ToolCallID: call.ID, Content: err.Error(), IsError: true}, nil
+		}
+		return api.CallResult{
+			ToolCallID: call.ID,
+			Content:    extractTextFromContent(resp.Content),
+			IsError:    resp.IsError,
+		}, nil
+	}
+}
+
+// confirmOnStdin asks the user on stdin/stdout whether a side-effectful
+// tool call (e.g. trigger_job) should proceed.
+func confirmOnStdin(call api.ToolCall) bool {
+	fmt.Printf("→ about to call %q with %v — proceed? [y/N] ", call.Name, call.Arguments)
+	reader := bufio.NewReader(os.Stdin)
+	line, _ := reader.ReadString('\n')
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "y")
+}
+
+// extractTextFromContent flattens []mcp.Content into a readable string
+func extractTextFromContent(contents []mcp.Content) string {
+	var sb strings.Builder
+	for _, c := range contents {
+		switch v := c.(type) {
+		case *mcp.TextContent:
+			sb.WriteString(v.Text)
+			sb.WriteString("\n")
+		default:
+			sb.WriteString(fmt.Sprintf("%v\n", v))
+		}
+	}
+	return strings.TrimSpace(sb.String())
 }
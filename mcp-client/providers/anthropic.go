@@ -0,0 +1,189 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+)
+
+const anthropicAPIURL = "https://api.anthropic.com/v1/messages"
+
+// AnthropicProvider implements api.ChatCompletionProvider against the
+// Anthropic Messages API, translating tool calls to/from its native
+// tool_use/tool_result content blocks.
+type AnthropicProvider struct {
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewAnthropic builds an AnthropicProvider. model defaults to
+// "claude-sonnet-4-5".
+func NewAnthropic(apiKey, model string) *AnthropicProvider {
+	if model == "" {
+		model = "claude-sonnet-4-5"
+	}
+	return &AnthropicProvider{apiKey: apiKey, model: model, httpClient: http.DefaultClient}
+}
+
+func (p *AnthropicProvider) Name() string { return "anthropic" }
+
+type anthropicContentBlock struct {
+	Type      string         `json:"type"`
+	Text      string         `json:"text,omitempty"`
+	ID        string         `json:"id,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Input     map[string]any `json:"input,omitempty"`
+	ToolUseID string         `json:"tool_use_id,omitempty"`
+	Content   string         `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicContentBlock `json:"content"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *AnthropicProvider) CreateChatCompletion(ctx context.Context, messages []api.Message, tools []api.ToolSpec) (api.Message, error) {
+	system, anthMessages := toAnthropicMessages(messages)
+
+	reqBody := anthropicRequest{
+		Model:     p.model,
+		MaxTokens: 4096,
+		System:    system,
+		Messages:  anthMessages,
+		Tools:     toAnthropicTools(tools),
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("anthropic: encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, anthropicAPIURL, bytes.NewReader(payload))
+	if err != nil {
+		return api.Message{}, fmt.Errorf("anthropic: build request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("anthropic: read response: %w", err)
+	}
+
+	var parsed anthropicResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return api.Message{}, fmt.Errorf("anthropic: decode response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		if parsed.Error != nil {
+			return api.Message{}, fmt.Errorf("anthropic: status=%d: %s", resp.StatusCode, parsed.Error.Message)
+		}
+		return api.Message{}, fmt.Errorf("anthropic: status=%d: %s", resp.StatusCode, string(body))
+	}
+
+	return fromAnthropicContent(parsed.Content), nil
+}
+
+func toAnthropicMessages(messages []api.Message) (string, []anthropicMessage) {
+	var system string
+	var out []anthropicMessage
+
+	for _, m := range messages {
+		switch m.Role {
+		case "system":
+			system = m.Content
+		case "tool":
+			out = append(out, anthropicMessage{
+				Role: "user",
+				Content: []anthropicContentBlock{{
+					Type:      "tool_result",
+					ToolUseID: m.ToolCallID,
+					Content:   m.Content,
+				}},
+			})
+		case "assistant":
+			blocks := []anthropicContentBlock{}
+			if m.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Name,
+					Input: tc.Arguments,
+				})
+			}
+			out = append(out, anthropicMessage{Role: "assistant", Content: blocks})
+		default: // "user"
+			out = append(out, anthropicMessage{
+				Role:    "user",
+				Content: []anthropicContentBlock{{Type: "text", Text: m.Content}},
+			})
+		}
+	}
+	return system, out
+}
+
+func toAnthropicTools(tools []api.ToolSpec) []anthropicTool {
+	out := make([]anthropicTool, 0, len(tools))
+	for _, t := range tools {
+		out = append(out, anthropicTool{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: t.InputSchema,
+		})
+	}
+	return out
+}
+
+func fromAnthropicContent(blocks []anthropicContentBlock) api.Message {
+	out := api.Message{Role: "assistant"}
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			out.Content += b.Text
+		case "tool_use":
+			out.ToolCalls = append(out.ToolCalls, api.ToolCall{
+				ID:        b.ID,
+				Name:      b.Name,
+				Arguments: b.Input,
+			})
+		}
+	}
+	return out
+}
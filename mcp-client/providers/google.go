@@ -0,0 +1,192 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+)
+
+const googleAPIBaseURL = "https://generativelanguage.googleapis.com/v1beta/models"
+
+// GoogleProvider implements api.ChatCompletionProvider against the
+// Gemini generateContent API, translating tool calls to/from its native
+// functionCall/functionResponse parts.
+type GoogleProvider struct {
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewGoogle builds a GoogleProvider. model defaults to "gemini-1.5-pro".
+func NewGoogle(apiKey, model string) *GoogleProvider {
+	if model == "" {
+		model = "gemini-1.5-pro"
+	}
+	return &GoogleProvider{apiKey: apiKey, model: model, httpClient: http.DefaultClient}
+}
+
+func (p *GoogleProvider) Name() string { return "google" }
+
+type googlePart struct {
+	Text             string          `json:"text,omitempty"`
+	FunctionCall     *googleFuncCall `json:"functionCall,omitempty"`
+	FunctionResponse *googleFuncResp `json:"functionResponse,omitempty"`
+}
+
+type googleFuncCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args"`
+}
+
+type googleFuncResp struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type googleContent struct {
+	Role  string       `json:"role"`
+	Parts []googlePart `json:"parts"`
+}
+
+type googleFunctionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type googleTool struct {
+	FunctionDeclarations []googleFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type googleRequest struct {
+	Contents          []googleContent `json:"contents"`
+	Tools             []googleTool    `json:"tools,omitempty"`
+	SystemInstruction *googleContent  `json:"systemInstruction,omitempty"`
+}
+
+type googleResponse struct {
+	Candidates []struct {
+		Content googleContent `json:"content"`
+	} `json:"candidates"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *GoogleProvider) CreateChatCompletion(ctx context.Context, messages []api.Message, tools []api.ToolSpec) (api.Message, error) {
+	system, contents := toGoogleContents(messages)
+
+	reqBody := googleRequest{Contents: contents}
+	if system != nil {
+		reqBody.SystemInstruction = system
+	}
+	if len(tools) > 0 {
+		reqBody.Tools = []googleTool{{FunctionDeclarations: toGoogleFunctionDeclarations(tools)}}
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("google: encode request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s:generateContent?key=%s", googleAPIBaseURL, p.model, p.apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return api.Message{}, fmt.Errorf("google: build request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("google: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("google: read response: %w", err)
+	}
+
+	var parsed googleResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return api.Message{}, fmt.Errorf("google: decode response: %w", err)
+	}
+	if parsed.Error != nil {
+		return api.Message{}, fmt.Errorf("google: %s", parsed.Error.Message)
+	}
+	if len(parsed.Candidates) == 0 {
+		return api.Message{}, fmt.Errorf("google: empty response")
+	}
+
+	return fromGoogleContent(parsed.Candidates[0].Content), nil
+}
+
+func toGoogleContents(messages []api.Message) (*googleContent, []googleContent) {
+	var system *googleContent
+	var out []googleContent
+
+	for _, m := range messages {
+		switch m.Role {
+		case "system":
+			system = &googleContent{Parts: []googlePart{{Text: m.Content}}}
+		case "tool":
+			out = append(out, googleContent{
+				Role: "user",
+				Parts: []googlePart{{
+					FunctionResponse: &googleFuncResp{
+						Name:     m.ToolCallID,
+						Response: map[string]any{"content": m.Content},
+					},
+				}},
+			})
+		case "assistant":
+			parts := []googlePart{}
+			if m.Content != "" {
+				parts = append(parts, googlePart{Text: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				parts = append(parts, googlePart{FunctionCall: &googleFuncCall{Name: tc.Name, Args: tc.Arguments}})
+			}
+			out = append(out, googleContent{Role: "model", Parts: parts})
+		default: // "user"
+			out = append(out, googleContent{Role: "user", Parts: []googlePart{{Text: m.Content}}})
+		}
+	}
+	return system, out
+}
+
+func toGoogleFunctionDeclarations(tools []api.ToolSpec) []googleFunctionDeclaration {
+	out := make([]googleFunctionDeclaration, 0, len(tools))
+	for _, t := range tools {
+		out = append(out, googleFunctionDeclaration{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  t.InputSchema,
+		})
+	}
+	return out
+}
+
+func fromGoogleContent(content googleContent) api.Message {
+	out := api.Message{Role: "assistant"}
+	for _, part := range content.Parts {
+		if part.Text != "" {
+			out.Content += part.Text
+		}
+		if part.FunctionCall != nil {
+			// Gemini doesn't assign call IDs; functionResponse matches
+			// back to functionCall by name, so use the name as the ID.
+			out.ToolCalls = append(out.ToolCalls, api.ToolCall{
+				ID:        part.FunctionCall.Name,
+				Name:      part.FunctionCall.Name,
+				Arguments: part.FunctionCall.Args,
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+)
+
+// OllamaProvider implements api.ChatCompletionProvider against a local
+// Ollama server's OpenAI-style /api/chat tool-calling support.
+type OllamaProvider struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOllama builds an OllamaProvider. baseURL defaults to
+// "http://localhost:11434"; model defaults to "llama3.1".
+func NewOllama(baseURL, model string) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	if model == "" {
+		model = "llama3.1"
+	}
+	return &OllamaProvider{baseURL: baseURL, model: model, httpClient: http.DefaultClient}
+}
+
+func (p *OllamaProvider) Name() string { return "ollama" }
+
+type ollamaFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type ollamaTool struct {
+	Type     string         `json:"type"`
+	Function ollamaFunction `json:"function"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+	Error   string        `json:"error"`
+}
+
+func (p *OllamaProvider) CreateChatCompletion(ctx context.Context, messages []api.Message, tools []api.ToolSpec) (api.Message, error) {
+	reqBody := ollamaRequest{
+		Model:    p.model,
+		Messages: toOllamaMessages(messages),
+		Tools:    toOllamaTools(tools),
+		Stream:   false,
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("ollama: encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/chat", bytes.NewReader(payload))
+	if err != nil {
+		return api.Message{}, fmt.Errorf("ollama: build request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return api.Message{}, fmt.Errorf("ollama: read response: %w", err)
+	}
+
+	var parsed ollamaResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return api.Message{}, fmt.Errorf("ollama: decode response: %w", err)
+	}
+	if parsed.Error != "" {
+		return api.Message{}, fmt.Errorf("ollama: %s", parsed.Error)
+	}
+
+	return fromOllamaMessage(parsed.Message), nil
+}
+
+func toOllamaMessages(messages []api.Message) []ollamaMessage {
+	out := make([]ollamaMessage, 0, len(messages))
+	for _, m := range messages {
+		role := m.Role
+		if role == "tool" {
+			// Ollama has no dedicated tool role; fold the result back in
+			// as a user turn so the model sees it on the next request.
+			role = "user"
+		}
+		msg := ollamaMessage{Role: role, Content: m.Content}
+		for _, tc := range m.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = tc.Arguments
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+func toOllamaTools(tools []api.ToolSpec) []ollamaTool {
+	out := make([]ollamaTool, 0, len(tools))
+	for _, t := range tools {
+		out = append(out, ollamaTool{
+			Type: "function",
+			Function: ollamaFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.InputSchema,
+			},
+		})
+	}
+	return out
+}
+
+func fromOllamaMessage(msg ollamaMessage) api.Message {
+	out := api.Message{Role: "assistant", Content: msg.Content}
+	for i, tc := range msg.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, api.ToolCall{
+			// Ollama doesn't assign tool-call IDs; synthesize one so the
+			// agent loop can correlate it with a CallResult.
+			ID:        "ollama-call-" + strconv.Itoa(i),
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+)
+
+// OpenAIProvider implements api.ChatCompletionProvider on top of OpenAI's
+// native function-calling API.
+type OpenAIProvider struct {
+	client *openai.Client
+	model  string
+}
+
+// NewOpenAI builds an OpenAIProvider. model defaults to "gpt-4o".
+func NewOpenAI(apiKey, model string) *OpenAIProvider {
+	if model == "" {
+		model = "gpt-4o"
+	}
+	return &OpenAIProvider{client: openai.NewClient(apiKey), model: model}
+}
+
+func (p *OpenAIProvider) Name() string { return "openai" }
+
+func (p *OpenAIProvider) CreateChatCompletion(ctx context.Context, messages []api.Message, tools []api.ToolSpec) (api.Message, error) {
+	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    p.model,
+		Messages: toOpenAIMessages(messages),
+		Tools:    toOpenAITools(tools),
+	})
+	if err != nil {
+		return api.Message{}, fmt.Errorf("openai: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return api.Message{}, fmt.Errorf("openai: empty response")
+	}
+	return fromOpenAIMessage(resp.Choices[0].Message), nil
+}
+
+func toOpenAIMessages(messages []api.Message) []openai.ChatCompletionMessage {
+	out := make([]openai.ChatCompletionMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := openai.ChatCompletionMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCallID: m.ToolCallID,
+		}
+		for _, tc := range m.ToolCalls {
+			args, _ := json.Marshal(tc.Arguments)
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:   tc.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      tc.Name,
+					Arguments: string(args),
+				},
+			})
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+func toOpenAITools(tools []api.ToolSpec) []openai.Tool {
+	out := make([]openai.Tool, 0, len(tools))
+	for _, t := range tools {
+		out = append(out, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.InputSchema,
+			},
+		})
+	}
+	return out
+}
+
+func fromOpenAIMessage(msg openai.ChatCompletionMessage) api.Message {
+	out := api.Message{Role: msg.Role, Content: msg.Content}
+	for _, tc := range msg.ToolCalls {
+		var args map[string]any
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+			args = map[string]any{}
+		}
+		out.ToolCalls = append(out.ToolCalls, api.ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: args,
+		})
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+)
+
+// New builds the ChatCompletionProvider named by providerName (as read
+// from the LLM_PROVIDER env var, defaulting to "openai"), picking up
+// each backend's API key/base URL from its usual environment variable.
+func New(providerName, model string) (api.ChatCompletionProvider, error) {
+	switch providerName {
+	case "", "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
+		}
+		return NewOpenAI(apiKey, model), nil
+	case "anthropic":
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
+		}
+		return NewAnthropic(apiKey, model), nil
+	case "ollama":
+		return NewOllama(os.Getenv("OLLAMA_BASE_URL"), model), nil
+	case "google":
+		apiKey := os.Getenv("GOOGLE_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("GOOGLE_API_KEY environment variable not set")
+		}
+		return NewGoogle(apiKey, model), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q (want openai, anthropic, ollama, or google)", providerName)
+	}
+}
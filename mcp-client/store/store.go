@@ -0,0 +1,283 @@
+// Package store persists conversations in a local SQLite database so
+// Jenkins troubleshooting sessions can be resumed, branched, and
+// reviewed without re-fetching logs that were already pulled in an
+// earlier turn.
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+)
+
+// Store wraps a SQLite connection holding the conversations and
+// messages tables.
+type Store struct {
+	db *sql.DB
+}
+
+// Open creates (if needed) and opens the SQLite database at path,
+// creating its parent directory too since sqlite won't do that itself.
+func Open(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("open store: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate store: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error { return s.db.Close() }
+
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS conversations (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			title      TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS messages (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			conversation_id INTEGER NOT NULL REFERENCES conversations(id),
+			parent_id       INTEGER REFERENCES messages(id),
+			role            TEXT NOT NULL,
+			content         TEXT NOT NULL,
+			tool_call_id    TEXT NOT NULL DEFAULT '',
+			tool_calls_json TEXT NOT NULL DEFAULT '',
+			created_at      TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_id);
+		CREATE INDEX IF NOT EXISTS idx_messages_parent ON messages(parent_id);
+	`)
+	return err
+}
+
+// Conversation is one conversation's metadata (its messages form a tree,
+// not a list, since any message can be the parent of multiple replies).
+type Conversation struct {
+	ID        int64
+	Title     string
+	CreatedAt time.Time
+}
+
+// StoredMessage is a Message plus the store bookkeeping (ID/ParentID)
+// needed to reconstruct branches.
+type StoredMessage struct {
+	ID        int64
+	ParentID  sql.NullInt64
+	CreatedAt time.Time
+	api.Message
+}
+
+// NewConversation creates a conversation and its root message (normally
+// the agent's system prompt), returning both IDs.
+func (s *Store) NewConversation(title string, root api.Message) (conversationID, rootMessageID int64, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := tx.Exec(`INSERT INTO conversations (title, created_at) VALUES (?, ?)`, title, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	conversationID, err = res.LastInsertId()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rootMessageID, err = insertMessage(tx, conversationID, sql.NullInt64{}, root, now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return conversationID, rootMessageID, tx.Commit()
+}
+
+// AppendMessages inserts messages as a chain of children under parentID,
+// each one the parent of the next, returning the ID of the last message
+// inserted (the new leaf). Editing a past message and passing its ID as
+// parentID creates a branch rather than continuing the original thread.
+func (s *Store) AppendMessages(conversationID, parentID int64, messages []api.Message) (leafID int64, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	parent := sql.NullInt64{Int64: parentID, Valid: true}
+	for _, msg := range messages {
+		id, err := insertMessage(tx, conversationID, parent, msg, now)
+		if err != nil {
+			return 0, err
+		}
+		parent = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return parent.Int64, nil
+}
+
+func insertMessage(tx *sql.Tx, conversationID int64, parentID sql.NullInt64, msg api.Message, createdAt string) (int64, error) {
+	toolCallsJSON, err := marshalToolCalls(msg.ToolCalls)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(
+		`INSERT INTO messages (conversation_id, parent_id, role, content, tool_call_id, tool_calls_json, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		conversationID, parentID, msg.Role, msg.Content, msg.ToolCallID, toolCallsJSON, createdAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// LatestLeaf returns the ID of the most recently created message with no
+// children in the conversation, i.e. the tip of its most recent branch.
+func (s *Store) LatestLeaf(conversationID int64) (int64, error) {
+	row := s.db.QueryRow(`
+		SELECT m.id FROM messages m
+		WHERE m.conversation_id = ?
+		  AND NOT EXISTS (SELECT 1 FROM messages c WHERE c.parent_id = m.id)
+		ORDER BY m.id DESC LIMIT 1`, conversationID)
+	var id int64
+	if err := row.Scan(&id); err != nil {
+		return 0, fmt.Errorf("latest leaf: %w", err)
+	}
+	return id, nil
+}
+
+// Thread walks the parent chain from leafID back to the conversation
+// root and returns the messages in chronological (root-first) order.
+func (s *Store) Thread(leafID int64) ([]StoredMessage, error) {
+	var reversed []StoredMessage
+
+	id := sql.NullInt64{Int64: leafID, Valid: true}
+	for id.Valid {
+		msg, parentID, err := s.loadMessage(id.Int64)
+		if err != nil {
+			return nil, err
+		}
+		reversed = append(reversed, msg)
+		id = parentID
+	}
+
+	thread := make([]StoredMessage, len(reversed))
+	for i, m := range reversed {
+		thread[len(reversed)-1-i] = m
+	}
+	return thread, nil
+}
+
+func (s *Store) loadMessage(id int64) (StoredMessage, sql.NullInt64, error) {
+	var (
+		m             StoredMessage
+		parentID      sql.NullInt64
+		toolCallsJSON string
+		createdAt     string
+	)
+	row := s.db.QueryRow(`SELECT id, parent_id, role, content, tool_call_id, tool_calls_json, created_at FROM messages WHERE id = ?`, id)
+	if err := row.Scan(&m.ID, &parentID, &m.Role, &m.Content, &m.ToolCallID, &toolCallsJSON, &createdAt); err != nil {
+		return StoredMessage{}, sql.NullInt64{}, fmt.Errorf("load message %d: %w", id, err)
+	}
+	toolCalls, err := unmarshalToolCalls(toolCallsJSON)
+	if err != nil {
+		return StoredMessage{}, sql.NullInt64{}, err
+	}
+	m.ToolCalls = toolCalls
+	m.ParentID = parentID
+	if t, err := time.Parse(time.RFC3339Nano, createdAt); err == nil {
+		m.CreatedAt = t
+	}
+	return m, parentID, nil
+}
+
+// ListConversations returns every conversation, most recent first.
+func (s *Store) ListConversations() ([]Conversation, error) {
+	rows, err := s.db.Query(`SELECT id, title, created_at FROM conversations ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Conversation
+	for rows.Next() {
+		var c Conversation
+		var createdAt string
+		if err := rows.Scan(&c.ID, &c.Title, &createdAt); err != nil {
+			return nil, err
+		}
+		c.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// RemoveConversation deletes a conversation and all of its messages.
+func (s *Store) RemoveConversation(conversationID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM messages WHERE conversation_id = ?`, conversationID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM conversations WHERE id = ?`, conversationID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Tree returns every message in a conversation (across all branches),
+// for the TUI's branch navigator.
+func (s *Store) Tree(conversationID int64) ([]StoredMessage, error) {
+	rows, err := s.db.Query(`SELECT id, parent_id, role, content, tool_call_id, tool_calls_json, created_at FROM messages WHERE conversation_id = ? ORDER BY id ASC`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []StoredMessage
+	for rows.Next() {
+		var (
+			m             StoredMessage
+			toolCallsJSON string
+			createdAt     string
+		)
+		if err := rows.Scan(&m.ID, &m.ParentID, &m.Role, &m.Content, &m.ToolCallID, &toolCallsJSON, &createdAt); err != nil {
+			return nil, err
+		}
+		toolCalls, err := unmarshalToolCalls(toolCallsJSON)
+		if err != nil {
+			return nil, err
+		}
+		m.ToolCalls = toolCalls
+		m.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/api"
+)
+
+func marshalToolCalls(calls []api.ToolCall) (string, error) {
+	if len(calls) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(calls)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func unmarshalToolCalls(raw string) ([]api.ToolCall, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var calls []api.ToolCall
+	if err := json.Unmarshal([]byte(raw), &calls); err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
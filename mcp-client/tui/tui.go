@@ -0,0 +1,117 @@
+// Package tui is a bubbletea-based browser for a conversation's branch
+// tree, so a user can see where a past message was edited and re-prompted
+// and jump to any branch tip without re-fetching logs that were already
+// pulled on another branch.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/StackBalancer/jenkins-mcp/mcp-client/store"
+)
+
+type node struct {
+	msg      store.StoredMessage
+	depth    int
+	children []int // indices into model.rows of this node's children
+}
+
+type model struct {
+	rows     []node
+	cursor   int
+	selected int64 // selected message ID, 0 if the user quit without choosing
+	done     bool
+}
+
+// Browse renders messages (a flat Store.Tree() result) as an
+// indented tree and lets the user navigate it with j/k/enter/q, vi-style.
+// It returns the message ID the user picked (normally a leaf, to resume
+// or view that branch) and false if they quit without picking one.
+func Browse(messages []store.StoredMessage) (int64, bool, error) {
+	m := buildModel(messages)
+	p := tea.NewProgram(m)
+	final, err := p.Run()
+	if err != nil {
+		return 0, false, fmt.Errorf("tui: %w", err)
+	}
+	fm := final.(model)
+	return fm.selected, fm.selected != 0, nil
+}
+
+func buildModel(messages []store.StoredMessage) model {
+	depth := map[int64]int{}
+	rows := make([]node, len(messages))
+	indexByID := map[int64]int{}
+
+	for i, msg := range messages {
+		d := 0
+		if msg.ParentID.Valid {
+			d = depth[msg.ParentID.Int64] + 1
+		}
+		depth[msg.ID] = d
+		rows[i] = node{msg: msg, depth: d}
+		indexByID[msg.ID] = i
+	}
+	for i, msg := range messages {
+		if msg.ParentID.Valid {
+			if pi, ok := indexByID[msg.ParentID.Int64]; ok {
+				rows[pi].children = append(rows[pi].children, i)
+			}
+		}
+	}
+
+	return model{rows: rows}
+}
+
+func (m model) Init() tea.Cmd { return nil }
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "j", "down":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "enter", "l":
+		if len(m.rows) > 0 {
+			m.selected = m.rows[m.cursor].msg.ID
+		}
+		m.done = true
+		return m, tea.Quit
+	case "q", "esc", "ctrl+c":
+		m.done = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	if m.done {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("conversation branches (j/k move, enter select, q quit)\n\n")
+	for i, r := range m.rows {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		indent := strings.Repeat("  ", r.depth)
+		preview := r.msg.Content
+		if len(preview) > 60 {
+			preview = preview[:60] + "..."
+		}
+		b.WriteString(fmt.Sprintf("%s%s[%d] %s: %s\n", cursor, indent, r.msg.ID, r.msg.Role, preview))
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/cookiejar"
+	"sync"
+)
+
+// crumb caches a Jenkins CSRF crumb, along with the header it must be
+// sent on. Jenkins calls the header name crumbRequestField; it's usually
+// "Jenkins-Crumb" but is configurable, so we always read it back from
+// /crumbIssuer/api/json rather than hard-coding it.
+type crumb struct {
+	mu     sync.Mutex
+	field  string
+	value  string
+	loaded bool
+}
+
+type crumbIssuerResponse struct {
+	CrumbRequestField string `json:"crumbRequestField"`
+	Crumb             string `json:"crumb"`
+}
+
+// fetchCrumb GETs /crumbIssuer/api/json and caches the result. Jenkins
+// instances without CSRF protection enabled 404 here, which we treat as
+// "no crumb needed" rather than an error. Any other error (network
+// failure, 5xx, ...) is NOT cached as "no crumb" and is returned to the
+// caller, so a transient failure gets retried on the next mutating
+// request instead of permanently disabling crumb attachment.
+func (jc *JenkinsClient) fetchCrumb(ctx context.Context) error {
+	data, _, err := jc.doRaw(ctx, "GET", "/crumbIssuer/api/json", nil, nil)
+	if err != nil {
+		if !isNotFound(err) {
+			return fmt.Errorf("fetch crumb issuer: %w", err)
+		}
+		jc.crumb.mu.Lock()
+		jc.crumb.loaded = true
+		jc.crumb.field = ""
+		jc.crumb.value = ""
+		jc.crumb.mu.Unlock()
+		return nil
+	}
+
+	var parsed crumbIssuerResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return fmt.Errorf("decode crumb issuer response: %w", err)
+	}
+
+	jc.crumb.mu.Lock()
+	jc.crumb.field = parsed.CrumbRequestField
+	jc.crumb.value = parsed.Crumb
+	jc.crumb.loaded = true
+	jc.crumb.mu.Unlock()
+	return nil
+}
+
+// crumbHeader returns the cached crumb header, fetching it first if this
+// is the first mutating request of the session.
+func (jc *JenkinsClient) crumbHeader(ctx context.Context) (field, value string, err error) {
+	jc.crumb.mu.Lock()
+	loaded := jc.crumb.loaded
+	jc.crumb.mu.Unlock()
+
+	if !loaded {
+		if err := jc.fetchCrumb(ctx); err != nil {
+			return "", "", err
+		}
+	}
+
+	jc.crumb.mu.Lock()
+	defer jc.crumb.mu.Unlock()
+	return jc.crumb.field, jc.crumb.value, nil
+}
+
+// invalidateCrumb forces the next mutating request to re-fetch the
+// crumb, used when a request comes back 403 (the crumb may have gone
+// stale, e.g. after a Jenkins restart).
+func (jc *JenkinsClient) invalidateCrumb() {
+	jc.crumb.mu.Lock()
+	jc.crumb.loaded = false
+	jc.crumb.mu.Unlock()
+}
+
+func newCookieJar() *cookiejar.Jar {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		// cookiejar.New only fails given a bad PublicSuffixList, and we
+		// pass nil (the default list), so this is unreachable in practice.
+		panic(fmt.Sprintf("cookiejar.New: %v", err))
+	}
+	return jar
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	testCrumbField = "Jenkins-Crumb"
+	testCrumbValue = "abc123"
+)
+
+func newCrumbIssuerHandler(t *testing.T, target http.Handler) http.Handler {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/crumbIssuer/api/json", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(crumbIssuerResponse{
+			CrumbRequestField: testCrumbField,
+			Crumb:             testCrumbValue,
+		})
+	})
+	mux.Handle("/", target)
+	return mux
+}
+
+func TestDoWithHeaders_CrumbHeaderByMethod(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		wantHeader bool
+	}{
+		{name: "GET has no crumb header", method: http.MethodGet, wantHeader: false},
+		{name: "POST has a crumb header", method: http.MethodPost, wantHeader: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeader string
+			target := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader = r.Header.Get(testCrumbField)
+				w.WriteHeader(http.StatusOK)
+			})
+			srv := httptest.NewServer(newCrumbIssuerHandler(t, target))
+			defer srv.Close()
+
+			jc := NewJenkinsClient(srv.URL, "", "")
+			if _, err := jc.do(context.Background(), tt.method, "/job/demo/build", nil, nil); err != nil {
+				t.Fatalf("do: %v", err)
+			}
+
+			if tt.wantHeader && gotHeader != testCrumbValue {
+				t.Errorf("crumb header = %q, want %q", gotHeader, testCrumbValue)
+			}
+			if !tt.wantHeader && gotHeader != "" {
+				t.Errorf("crumb header = %q, want empty", gotHeader)
+			}
+		})
+	}
+}
+
+func TestDoWithHeaders_StaleCrumbTriggersRefetch(t *testing.T) {
+	var (
+		crumbFetches int
+		calls        int
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/crumbIssuer/api/json", func(w http.ResponseWriter, r *http.Request) {
+		crumbFetches++
+		_ = json.NewEncoder(w).Encode(crumbIssuerResponse{
+			CrumbRequestField: testCrumbField,
+			Crumb:             testCrumbValue,
+		})
+	})
+	mux.HandleFunc("/job/demo/build", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// Simulate a stale crumb: the first mutating request is
+			// rejected, which should force one crumb refresh and retry.
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	jc := NewJenkinsClient(srv.URL, "", "")
+	if _, err := jc.do(context.Background(), http.MethodPost, "/job/demo/build", nil, nil); err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("target endpoint called %d times, want 2 (initial 403 + retry)", calls)
+	}
+	if crumbFetches != 2 {
+		t.Errorf("crumb issuer fetched %d times, want 2 (initial load + refetch after 403)", crumbFetches)
+	}
+}
+
+func TestFetchCrumb_NotFoundMeansNoCSRF(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/crumbIssuer/api/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	jc := NewJenkinsClient(srv.URL, "", "")
+	if err := jc.fetchCrumb(context.Background()); err != nil {
+		t.Fatalf("fetchCrumb: %v", err)
+	}
+	if !jc.crumb.loaded {
+		t.Error("crumb.loaded = false, want true after a 404")
+	}
+	if jc.crumb.field != "" || jc.crumb.value != "" {
+		t.Errorf("crumb = %q/%q, want empty after a 404", jc.crumb.field, jc.crumb.value)
+	}
+}
+
+func TestFetchCrumb_RealErrorDoesNotDisableCrumb(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/crumbIssuer/api/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	jc := NewJenkinsClient(srv.URL, "", "")
+	if err := jc.fetchCrumb(context.Background()); err == nil {
+		t.Fatal("fetchCrumb: want error on 500, got nil")
+	}
+	if jc.crumb.loaded {
+		t.Error("crumb.loaded = true after a real error, want false so the next request retries")
+	}
+}
@@ -10,26 +10,90 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Minimal Jenkins client
 type JenkinsClient struct {
-	Base  string
-	User  string
-	Token string
+	Base   string
+	User   string
+	Token  string
+	client *http.Client
+	crumb  crumb
 }
 
-func (jc *JenkinsClient) do(method, path string, params map[string]string, body io.Reader) ([]byte, error) {
-	req, err := http.NewRequest(method, jc.Base+path, body)
+// NewJenkinsClient builds a JenkinsClient with a cookie jar so the
+// session JSESSIONID persists across calls, halving auth overhead on
+// Jenkins instances that issue one.
+func NewJenkinsClient(base, user, token string) *JenkinsClient {
+	return &JenkinsClient{
+		Base:   base,
+		User:   user,
+		Token:  token,
+		client: &http.Client{Jar: newCookieJar()},
+	}
+}
+
+func (jc *JenkinsClient) do(ctx context.Context, method, path string, params map[string]string, body io.Reader) ([]byte, error) {
+	data, _, err := jc.doWithHeaders(ctx, method, path, params, body)
+	return data, err
+}
+
+// doWithHeaders is do, but also returns the response headers, for
+// endpoints like progressiveText where callers need X-Text-Size /
+// X-More-Data. Mutating requests (anything but GET) are crumb-protected:
+// the cached CSRF crumb is attached, and a 403 triggers one crumb
+// refresh-and-retry in case it went stale.
+func (jc *JenkinsClient) doWithHeaders(ctx context.Context, method, path string, params map[string]string, body io.Reader) ([]byte, http.Header, error) {
+	if method == http.MethodGet {
+		return jc.doRaw(ctx, method, path, params, body)
+	}
+
+	field, value, err := jc.crumbHeader(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("fetch csrf crumb: %w", err)
+	}
+
+	data, headers, err := jc.doRawWithCrumb(ctx, method, path, params, body, field, value)
+	if err == nil {
+		return data, headers, nil
+	}
+	if !isForbidden(err) {
+		return nil, nil, err
+	}
+
+	// Crumb may have gone stale (e.g. Jenkins restarted); refresh once
+	// and retry.
+	jc.invalidateCrumb()
+	field, value, err = jc.crumbHeader(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("refetch csrf crumb after 403: %w", err)
+	}
+	return jc.doRawWithCrumb(ctx, method, path, params, body, field, value)
+}
+
+// doRaw issues one HTTP request with no crumb header, used for GETs and
+// for fetching the crumb itself.
+func (jc *JenkinsClient) doRaw(ctx context.Context, method, path string, params map[string]string, body io.Reader) ([]byte, http.Header, error) {
+	return jc.doRawWithCrumb(ctx, method, path, params, body, "", "")
+}
+
+func (jc *JenkinsClient) doRawWithCrumb(ctx context.Context, method, path string, params map[string]string, body io.Reader, crumbField, crumbValue string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, jc.Base+path, body)
+	if err != nil {
+		return nil, nil, err
 	}
 	if jc.User != "" || jc.Token != "" {
 		req.SetBasicAuth(jc.User, jc.Token)
 	}
+	if crumbField != "" {
+		req.Header.Set(crumbField, crumbValue)
+	}
 
 	q := req.URL.Query()
 	for k, v := range params {
@@ -37,24 +101,39 @@ func (jc *JenkinsClient) do(method, path string, params map[string]string, body
 	}
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := jc.httpClient().Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	respBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("jenkins error: status=%d body=%s", resp.StatusCode, string(respBytes))
+		return nil, nil, fmt.Errorf("jenkins error: status=%d body=%s", resp.StatusCode, string(respBytes))
+	}
+	return respBytes, resp.Header, nil
+}
+
+func (jc *JenkinsClient) httpClient() *http.Client {
+	if jc.client != nil {
+		return jc.client
 	}
-	return respBytes, nil
+	return http.DefaultClient
+}
+
+func isForbidden(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "status=403")
+}
+
+func isNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "status=404")
 }
 
 // TriggerJob wraps jc.do() for build and buildWithParameters
-func (jc *JenkinsClient) TriggerJob(jobName string, params map[string]string) error {
+func (jc *JenkinsClient) TriggerJob(ctx context.Context, jobName string, params map[string]string) error {
 	path := fmt.Sprintf("/job/%s/build", jobName)
 	if len(params) > 0 {
 		path = fmt.Sprintf("/job/%s/buildWithParameters", jobName)
@@ -62,7 +141,7 @@ func (jc *JenkinsClient) TriggerJob(jobName string, params map[string]string) er
 
 	log.Printf("[DEBUG] Triggering job at path: %s with params: %+v", path, params)
 
-	_, err := jc.do("POST", path, params, nil)
+	_, err := jc.do(ctx, "POST", path, params, nil)
 	if err != nil {
 		return fmt.Errorf("failed to trigger job: %w", err)
 	}
@@ -81,11 +160,7 @@ func main() {
 	}
 	mcpJenkinsToken := strings.TrimSpace(string(mcpTokenBytes))
 
-	jc := &JenkinsClient{
-		Base:  jenkinsURL,
-		User:  os.Getenv("JENKINS_MCP_USER"),
-		Token: mcpJenkinsToken,
-	}
+	jc := NewJenkinsClient(jenkinsURL, os.Getenv("JENKINS_MCP_USER"), mcpJenkinsToken)
 
 	// MCP server
 	m := server.NewMCPServer("jenkins-mcp", "1.0.0")
@@ -113,7 +188,7 @@ func main() {
 			}
 		}
 
-		if err := jc.TriggerJob(jobNameVal, params); err != nil {
+		if err := jc.TriggerJob(ctx, jobNameVal, params); err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
 		return mcp.NewToolResultText("job triggered successfully!"), nil
@@ -127,7 +202,7 @@ func main() {
 	)
 	m.AddTool(statusTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		jobNameVal, _ := req.Params.Arguments.(map[string]any)["job_name"].(string)
-		data, err := jc.do("GET", fmt.Sprintf("/job/%s/lastBuild/api/json", jobNameVal), nil, nil)
+		data, err := jc.do(ctx, "GET", fmt.Sprintf("/job/%s/lastBuild/api/json", jobNameVal), nil, nil)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -146,29 +221,15 @@ func main() {
 		mcp.WithString("build_number", mcp.Description("build number (required)"), mcp.Required()),
 	)
 	m.AddTool(consoleTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		jobNameVal, _ := req.Params.Arguments.(map[string]any)["job_name"].(string)
+		args, _ := req.Params.Arguments.(map[string]any)
+		jobNameVal, _ := args["job_name"].(string)
 
-		var buildNumber int
-		if val, ok := req.Params.Arguments.(map[string]any)["build_number"]; ok {
-			switch v := val.(type) {
-			case int:
-				buildNumber = v
-			case float64:
-				buildNumber = int(v)
-			case string:
-				n, err := strconv.Atoi(v)
-				if err != nil {
-					return mcp.NewToolResultError("invalid build_number string"), nil
-				}
-				buildNumber = n
-			default:
-				return mcp.NewToolResultError("invalid build_number type"), nil
-			}
-		} else {
-			return mcp.NewToolResultError("missing build_number"), nil
+		buildNumber, err := intArg(args, "build_number")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
 
-		data, err := jc.do("GET", fmt.Sprintf("/job/%s/%d/consoleText", jobNameVal, buildNumber), nil, nil)
+		data, err := jc.do(ctx, "GET", fmt.Sprintf("/job/%s/%d/consoleText", jobNameVal, buildNumber), nil, nil)
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -179,10 +240,156 @@ func main() {
 		return mcp.NewToolResultText(logText), nil
 	})
 
-	// Start SSE server
+	// stream_console_log tool
+	streamConsoleTool := mcp.NewTool(
+		"stream_console_log",
+		mcp.WithDescription("Page through a build's console log via Jenkins's progressiveText endpoint, reporting progress notifications instead of buffering the whole log. Supports follow, tail_bytes, and grep."),
+		mcp.WithString("job_name", mcp.Description("job name (required)"), mcp.Required()),
+		mcp.WithString("build_number", mcp.Description("build number (required)"), mcp.Required()),
+		mcp.WithBoolean("follow", mcp.Description("poll until the build finishes (X-More-Data absent)")),
+		mcp.WithNumber("tail_bytes", mcp.Description("return only the last N bytes of the log")),
+		mcp.WithString("grep", mcp.Description("regex filter; only matching lines are returned")),
+	)
+	m.AddTool(streamConsoleTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return streamConsoleLog(ctx, jc, req)
+	})
+
+	// Job/node metrics subsystem
+	crawlCfg := JobCrawlerConfig{
+		MaxSubJobsLayer:        envInt("JENKINS_MAX_SUB_JOBS_LAYER", 0),
+		NewestSubJobsEachLayer: envInt("JENKINS_NEWEST_SUB_JOBS_EACH_LAYER", 0),
+		JobInclude:             envGlobList("JENKINS_JOB_INCLUDE"),
+		JobExclude:             envGlobList("JENKINS_JOB_EXCLUDE"),
+		MaxBuildAge:            envDuration("JENKINS_MAX_BUILD_AGE", 0),
+	}
+	jobCache := newJobTreeCache(envDuration("JENKINS_JOB_CACHE_TTL", 30*time.Second))
+	metrics := newJenkinsMetrics(prometheus.DefaultRegisterer)
+
+	// list_jobs tool
+	listJobsTool := mcp.NewTool(
+		"list_jobs",
+		mcp.WithDescription("Recursively list Jenkins jobs (and sub-jobs/folders), with each job's last build info."),
+	)
+	m.AddTool(listJobsTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tree, ok := jobCache.get()
+		if !ok {
+			var err error
+			tree, err = jc.CrawlJobs(ctx, crawlCfg)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			jobCache.set(tree)
+		}
+		return mcp.NewToolResultStructuredOnly(tree), nil
+	})
+
+	// list_nodes tool
+	listNodesTool := mcp.NewTool(
+		"list_nodes",
+		mcp.WithDescription("List Jenkins nodes (controller + agents) with executor busy/idle counts and online status."),
+	)
+	m.AddTool(listNodesTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		nodes, err := jc.CrawlNodes(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructuredOnly(nodes), nil
+	})
+
+	// get_queue tool
+	queueTool := mcp.NewTool(
+		"get_queue",
+		mcp.WithDescription("Get the current contents of the Jenkins build queue."),
+	)
+	m.AddTool(queueTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		items, err := jc.GetQueue(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructuredOnly(items), nil
+	})
+
+	// get_job_metrics tool
+	jobMetricsTool := mcp.NewTool(
+		"get_job_metrics",
+		mcp.WithDescription("Get last-build duration and result for a single job (by full name, e.g. 'folder/job')."),
+		mcp.WithString("job_name", mcp.Description("job name (required)"), mcp.Required()),
+	)
+	m.AddTool(jobMetricsTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		jobNameVal, _ := req.Params.Arguments.(map[string]any)["job_name"].(string)
+		tree, ok := jobCache.get()
+		if !ok {
+			var err error
+			tree, err = jc.CrawlJobs(ctx, crawlCfg)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			jobCache.set(tree)
+		}
+		var flat []*JobNode
+		flattenJobs(tree, &flat)
+		for _, j := range flat {
+			if j.FullName == jobNameVal || j.Name == jobNameVal {
+				return mcp.NewToolResultStructuredOnly(j), nil
+			}
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("job not found: %s", jobNameVal)), nil
+	})
+
+	// HTTP server: MCP SSE transport plus a Prometheus /metrics endpoint
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metricsHandler(metrics, jc, jobCache, crawlCfg))
 	sse := server.NewSSEServer(m)
-	log.Printf("starting SSE server on :8081")
-	if err := sse.Start(":8081"); err != nil {
-		log.Fatalf("sse server failed: %v", err)
+	mux.Handle("/", sse)
+
+	log.Printf("starting HTTP server on :8081 (MCP SSE + /metrics)")
+	if err := http.ListenAndServe(":8081", mux); err != nil {
+		log.Fatalf("http server failed: %v", err)
+	}
+}
+
+// metricsHandler refreshes the Jenkins gauges from a live crawl (subject
+// to jobCache's TTL) before delegating to the standard Prometheus handler.
+func metricsHandler(metrics *jenkinsMetrics, jc *JenkinsClient, cache *jobTreeCache, cfg JobCrawlerConfig) http.Handler {
+	promHandler := promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := metrics.refresh(r.Context(), jc, cache, cfg); err != nil {
+			log.Printf("[WARN] metrics refresh failed: %v", err)
+		}
+		promHandler.ServeHTTP(w, r)
+	})
+}
+
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("[WARN] invalid int for %s=%q, using default %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("[WARN] invalid duration for %s=%q, using default %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+func envGlobList(key string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
 	}
+	return strings.Split(v, ",")
 }
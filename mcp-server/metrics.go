@@ -0,0 +1,443 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// JobCrawlerConfig controls how deep and how wide CrawlJobs walks the
+// Jenkins job hierarchy, modeled on the Telegraf Jenkins input plugin's
+// knobs of the same name.
+type JobCrawlerConfig struct {
+	MaxSubJobsLayer        int           // 0 = unlimited depth
+	NewestSubJobsEachLayer int           // 0 = keep all sibling jobs at a layer
+	JobInclude             []string      // glob patterns; empty = include all
+	JobExclude             []string      // glob patterns; empty = exclude none
+	MaxBuildAge            time.Duration // 0 = no age filtering
+	Concurrency            int           // bounded goroutine pool size, default 8
+	RequestTimeout         time.Duration // per sub-job-fetch deadline, default 15s
+}
+
+func (c JobCrawlerConfig) concurrency() int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+	return 8
+}
+
+func (c JobCrawlerConfig) requestTimeout() time.Duration {
+	if c.RequestTimeout > 0 {
+		return c.RequestTimeout
+	}
+	return 15 * time.Second
+}
+
+// JobNode is one node in the crawled Jenkins job tree.
+type JobNode struct {
+	Name      string     `json:"name"`
+	FullName  string     `json:"full_name"`
+	LastBuild *BuildInfo `json:"last_build,omitempty"`
+	SubJobs   []*JobNode `json:"jobs,omitempty"`
+}
+
+// BuildInfo is the subset of a Jenkins build's JSON we surface in metrics
+// and in the get_job_metrics / list_jobs tool output.
+type BuildInfo struct {
+	Number    int    `json:"number"`
+	Result    string `json:"result"`
+	Duration  int64  `json:"duration_ms"`
+	Timestamp int64  `json:"timestamp_ms"`
+}
+
+type rawJobsResponse struct {
+	Jobs []rawJob `json:"jobs"`
+}
+
+type rawJob struct {
+	Name      string          `json:"name"`
+	LastBuild *rawBuild       `json:"lastBuild"`
+	Jobs      json.RawMessage `json:"jobs"`
+}
+
+type rawBuild struct {
+	Number    int    `json:"number"`
+	Result    string `json:"result"`
+	Duration  int64  `json:"duration"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// jobTreeCache memoizes the result of CrawlJobs for ttl so repeated
+// MCP tool calls (or /metrics scrapes) don't hammer Jenkins.
+type jobTreeCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	fetchedAt time.Time
+	tree      []*JobNode
+}
+
+func newJobTreeCache(ttl time.Duration) *jobTreeCache {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &jobTreeCache{ttl: ttl}
+}
+
+func (c *jobTreeCache) get() ([]*JobNode, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tree == nil || time.Since(c.fetchedAt) > c.ttl {
+		return nil, false
+	}
+	return c.tree, true
+}
+
+func (c *jobTreeCache) set(tree []*JobNode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tree = tree
+	c.fetchedAt = time.Now()
+}
+
+// CrawlJobs walks the Jenkins job hierarchy starting at the root,
+// recursing into sub-jobs (folders, multibranch pipelines, ...) up to
+// cfg.MaxSubJobsLayer deep. Only the leaf HTTP fetch at each layer is
+// bounded by a crawl-wide semaphore sized by cfg.Concurrency; the
+// semaphore is never held across a recursive descent, so a folder chain
+// deeper than cfg.Concurrency can't deadlock waiting for a token that a
+// blocked ancestor is holding. Each fetch gets its own cfg.RequestTimeout
+// deadline.
+func (jc *JenkinsClient) CrawlJobs(ctx context.Context, cfg JobCrawlerConfig) ([]*JobNode, error) {
+	sem := make(chan struct{}, cfg.concurrency())
+	return jc.crawlJobsAt(ctx, "", "", cfg, 0, sem)
+}
+
+// crawlJobsAt fetches one job-tree layer. urlPath is the Jenkins URL
+// segment for this layer (e.g. "/job/folder/job/child", rooted with a
+// leading slash, or empty for the root layer); fullName is the
+// human-readable job path (e.g.
+// "folder/child") used for display and metric labels. They diverge
+// because Jenkins URLs interleave a literal "job/" between each segment
+// but full names don't.
+func (jc *JenkinsClient) crawlJobsAt(ctx context.Context, urlPath, fullName string, cfg JobCrawlerConfig, depth int, sem chan struct{}) ([]*JobNode, error) {
+	apiPath := urlPath + "/api/json"
+
+	sem <- struct{}{}
+	reqCtx, cancel := context.WithTimeout(ctx, cfg.requestTimeout())
+	data, err := jc.do(reqCtx, "GET", apiPath, map[string]string{"tree": "jobs[name,lastBuild[number,result,duration,timestamp],jobs[name]]"}, nil)
+	cancel()
+	<-sem
+	if err != nil {
+		return nil, fmt.Errorf("crawl jobs at %q: %w", apiPath, err)
+	}
+
+	var resp rawJobsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("crawl jobs at %q: decode: %w", apiPath, err)
+	}
+
+	jobs := resp.Jobs
+	if cfg.NewestSubJobsEachLayer > 0 && len(jobs) > cfg.NewestSubJobsEachLayer {
+		sort.Slice(jobs, func(i, k int) bool {
+			return lastBuildTimestamp(jobs[i]) < lastBuildTimestamp(jobs[k])
+		})
+		jobs = jobs[len(jobs)-cfg.NewestSubJobsEachLayer:]
+	}
+
+	var (
+		mu    sync.Mutex
+		nodes []*JobNode
+		wg    sync.WaitGroup
+		errs  []error
+	)
+
+	for _, j := range jobs {
+		if !jobNameMatches(j.Name, cfg.JobInclude, cfg.JobExclude) {
+			continue
+		}
+		if cfg.MaxBuildAge > 0 && j.LastBuild != nil {
+			age := time.Since(time.UnixMilli(j.LastBuild.Timestamp))
+			if age > cfg.MaxBuildAge {
+				continue
+			}
+		}
+
+		childFullName := j.Name
+		if fullName != "" {
+			childFullName = fullName + "/" + j.Name
+		}
+		childURLPath := urlPath + "/job/" + url.PathEscape(j.Name)
+
+		node := &JobNode{Name: j.Name, FullName: childFullName}
+		if j.LastBuild != nil {
+			node.LastBuild = &BuildInfo{
+				Number:    j.LastBuild.Number,
+				Result:    j.LastBuild.Result,
+				Duration:  j.LastBuild.Duration,
+				Timestamp: j.LastBuild.Timestamp,
+			}
+		}
+
+		hasSubJobs := len(j.Jobs) > 0 && string(j.Jobs) != "null"
+		if !hasSubJobs || (cfg.MaxSubJobsLayer > 0 && depth+1 >= cfg.MaxSubJobsLayer) {
+			mu.Lock()
+			nodes = append(nodes, node)
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(n *JobNode, childURLPath, childFullName string) {
+			defer wg.Done()
+
+			sub, err := jc.crawlJobsAt(ctx, childURLPath, childFullName, cfg, depth+1, sem)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			n.SubJobs = sub
+			nodes = append(nodes, n)
+		}(node, childURLPath, childFullName)
+	}
+
+	wg.Wait()
+	if len(errs) > 0 {
+		return nodes, fmt.Errorf("crawl jobs at %q: %d sub-job fetches failed: %w", urlPath, len(errs), errs[0])
+	}
+	return nodes, nil
+}
+
+// lastBuildTimestamp returns a job's last build time for sorting by
+// recency, or 0 (oldest) for a job with no builds yet.
+func lastBuildTimestamp(j rawJob) int64 {
+	if j.LastBuild == nil {
+		return 0
+	}
+	return j.LastBuild.Timestamp
+}
+
+func jobNameMatches(name string, include, exclude []string) bool {
+	for _, pat := range exclude {
+		if ok, _ := filepath.Match(pat, name); ok {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pat := range include {
+		if ok, _ := filepath.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeInfo is the subset of a Jenkins computer (agent) JSON we surface.
+type NodeInfo struct {
+	DisplayName  string `json:"display_name"`
+	Offline      bool   `json:"offline"`
+	NumExecutors int    `json:"num_executors"`
+	BusyCount    int    `json:"busy_count"`
+	IdleCount    int    `json:"idle_count"`
+}
+
+type rawComputerResponse struct {
+	Computer []struct {
+		DisplayName  string `json:"displayName"`
+		Offline      bool   `json:"offline"`
+		NumExecutors int    `json:"numExecutors"`
+		Executors    []struct {
+			Idle bool `json:"idle"`
+		} `json:"executors"`
+	} `json:"computer"`
+}
+
+// CrawlNodes fetches executor/online-status stats for every Jenkins node
+// (the controller plus any agents) from /computer/api/json.
+func (jc *JenkinsClient) CrawlNodes(ctx context.Context) ([]NodeInfo, error) {
+	data, err := jc.do(ctx, "GET", "/computer/api/json", map[string]string{"tree": "computer[displayName,offline,numExecutors,executors[idle]]"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("crawl nodes: %w", err)
+	}
+
+	var resp rawComputerResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("crawl nodes: decode: %w", err)
+	}
+
+	nodes := make([]NodeInfo, 0, len(resp.Computer))
+	for _, c := range resp.Computer {
+		n := NodeInfo{
+			DisplayName:  c.DisplayName,
+			Offline:      c.Offline,
+			NumExecutors: c.NumExecutors,
+		}
+		for _, e := range c.Executors {
+			if e.Idle {
+				n.IdleCount++
+			} else {
+				n.BusyCount++
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// QueueItem is one entry in the Jenkins build queue.
+type QueueItem struct {
+	Task    string `json:"task"`
+	Why     string `json:"why"`
+	Blocked bool   `json:"blocked"`
+	Stuck   bool   `json:"stuck"`
+}
+
+// GetQueue fetches the current contents of the Jenkins build queue.
+func (jc *JenkinsClient) GetQueue(ctx context.Context) ([]QueueItem, error) {
+	data, err := jc.do(ctx, "GET", "/queue/api/json", map[string]string{"tree": "items[task[name],why,blocked,stuck]"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get queue: %w", err)
+	}
+
+	var resp struct {
+		Items []struct {
+			Task struct {
+				Name string `json:"name"`
+			} `json:"task"`
+			Why     string `json:"why"`
+			Blocked bool   `json:"blocked"`
+			Stuck   bool   `json:"stuck"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("get queue: decode: %w", err)
+	}
+
+	items := make([]QueueItem, 0, len(resp.Items))
+	for _, it := range resp.Items {
+		items = append(items, QueueItem{Task: it.Task.Name, Why: it.Why, Blocked: it.Blocked, Stuck: it.Stuck})
+	}
+	return items, nil
+}
+
+// jenkinsMetrics holds the Prometheus gauges published on /metrics.
+type jenkinsMetrics struct {
+	buildDuration *prometheus.GaugeVec
+	buildResult   *prometheus.GaugeVec
+	queueLength   prometheus.Gauge
+	executorBusy  prometheus.Gauge
+	executorIdle  prometheus.Gauge
+	nodeOnline    *prometheus.GaugeVec
+}
+
+func newJenkinsMetrics(reg prometheus.Registerer) *jenkinsMetrics {
+	m := &jenkinsMetrics{
+		buildDuration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "jenkins_build_duration_ms",
+			Help: "Duration of the last build of a job, in milliseconds.",
+		}, []string{"job"}),
+		buildResult: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "jenkins_build_result",
+			Help: "Last build result per job (1 = current state, labeled by result).",
+		}, []string{"job", "result"}),
+		queueLength: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "jenkins_queue_length",
+			Help: "Number of items currently in the Jenkins build queue.",
+		}),
+		executorBusy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "jenkins_executors_busy",
+			Help: "Number of busy executors across all nodes.",
+		}),
+		executorIdle: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "jenkins_executors_idle",
+			Help: "Number of idle executors across all nodes.",
+		}),
+		nodeOnline: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "jenkins_node_online",
+			Help: "Whether a Jenkins node is online (1) or offline (0).",
+		}, []string{"node"}),
+	}
+	reg.MustRegister(m.buildDuration, m.buildResult, m.queueLength, m.executorBusy, m.executorIdle, m.nodeOnline)
+	return m
+}
+
+var buildResults = []string{"SUCCESS", "FAILURE", "UNSTABLE", "ABORTED"}
+
+func flattenJobs(nodes []*JobNode, out *[]*JobNode) {
+	for _, n := range nodes {
+		*out = append(*out, n)
+		if len(n.SubJobs) > 0 {
+			flattenJobs(n.SubJobs, out)
+		}
+	}
+}
+
+// refresh re-crawls jobs and nodes and updates every gauge. It's called
+// on each /metrics scrape (through the cache, so scrapes within the TTL
+// reuse the last crawl).
+func (m *jenkinsMetrics) refresh(ctx context.Context, jc *JenkinsClient, cache *jobTreeCache, cfg JobCrawlerConfig) error {
+	tree, ok := cache.get()
+	if !ok {
+		var err error
+		tree, err = jc.CrawlJobs(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		cache.set(tree)
+	}
+
+	var flat []*JobNode
+	flattenJobs(tree, &flat)
+
+	m.buildDuration.Reset()
+	m.buildResult.Reset()
+	for _, j := range flat {
+		if j.LastBuild == nil {
+			continue
+		}
+		m.buildDuration.WithLabelValues(j.FullName).Set(float64(j.LastBuild.Duration))
+		for _, r := range buildResults {
+			val := 0.0
+			if j.LastBuild.Result == r {
+				val = 1.0
+			}
+			m.buildResult.WithLabelValues(j.FullName, r).Set(val)
+		}
+	}
+
+	queue, err := jc.GetQueue(ctx)
+	if err != nil {
+		return err
+	}
+	m.queueLength.Set(float64(len(queue)))
+
+	nodes, err := jc.CrawlNodes(ctx)
+	if err != nil {
+		return err
+	}
+	var busy, idle float64
+	m.nodeOnline.Reset()
+	for _, n := range nodes {
+		busy += float64(n.BusyCount)
+		idle += float64(n.IdleCount)
+		online := 1.0
+		if n.Offline {
+			online = 0.0
+		}
+		m.nodeOnline.WithLabelValues(n.DisplayName).Set(online)
+	}
+	m.executorBusy.Set(busy)
+	m.executorIdle.Set(idle)
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// progressivePollInterval is how long stream_console_log waits between
+// polls of /logText/progressiveText while following a running build.
+const progressivePollInterval = 2 * time.Second
+
+// streamConsoleLog pages through a build's console log via Jenkins's
+// progressiveText endpoint, reporting each chunk as an
+// "notifications/progress" message back to the MCP client instead of
+// buffering the whole log in memory (unlike get_console_log).
+func streamConsoleLog(ctx context.Context, jc *JenkinsClient, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, _ := req.Params.Arguments.(map[string]any)
+	jobName, _ := args["job_name"].(string)
+	if jobName == "" {
+		return mcp.NewToolResultError("job_name is required"), nil
+	}
+
+	buildNumber, err := intArg(args, "build_number")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	follow, _ := args["follow"].(bool)
+
+	var tailBytes int64
+	if v, ok := args["tail_bytes"]; ok {
+		n, err := intArg(args, "tail_bytes")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		tailBytes = int64(n)
+		_ = v
+	}
+
+	var grepRe *regexp.Regexp
+	if pattern, _ := args["grep"].(string); pattern != "" {
+		grepRe, err = regexp.Compile(pattern)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid grep pattern: %v", err)), nil
+		}
+	}
+
+	path := fmt.Sprintf("/job/%s/%d/logText/progressiveText", jobName, buildNumber)
+
+	var (
+		offset    int64
+		chunk     int
+		totalSize int64
+		tail      *tailBuffer
+		matched   strings.Builder
+	)
+	if tailBytes > 0 {
+		tail = newTailBuffer(tailBytes)
+	}
+	for {
+		text, size, moreData, err := jc.fetchProgressiveText(ctx, path, offset)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if text != "" {
+			chunk++
+			reportProgress(ctx, req, chunk, text)
+
+			// Filter/tail each chunk as it arrives instead of buffering
+			// the whole log, so following a long-running build can't
+			// exhaust memory.
+			filtered := text
+			if grepRe != nil {
+				filtered = grepLines(filtered, grepRe)
+				if filtered != "" {
+					filtered += "\n"
+				}
+			}
+			switch {
+			case tail != nil:
+				tail.Write(filtered)
+			case grepRe != nil:
+				matched.WriteString(filtered)
+			}
+		}
+		offset = size
+		totalSize = size
+
+		if !moreData {
+			break
+		}
+		if !follow {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return mcp.NewToolResultError(ctx.Err().Error()), nil
+		case <-time.After(progressivePollInterval):
+		}
+	}
+
+	switch {
+	case tail != nil:
+		return mcp.NewToolResultText(tail.String()), nil
+	case grepRe != nil:
+		return mcp.NewToolResultText(matched.String()), nil
+	default:
+		// No tail_bytes or grep filter was requested, and the log has
+		// already been delivered to the caller chunk-by-chunk via
+		// progress notifications; returning it again here would mean
+		// buffering the entire log in memory, the exact cost this tool
+		// exists to avoid.
+		return mcp.NewToolResultText(fmt.Sprintf("streamed %d chunk(s), %d bytes total; use tail_bytes or grep to get a final summary back instead of only progress notifications", chunk, totalSize)), nil
+	}
+}
+
+// tailBuffer keeps only the last n bytes written to it, so following a
+// build with tail_bytes set never holds more than n bytes of log text in
+// memory regardless of how long the build runs.
+type tailBuffer struct {
+	n   int64
+	buf []byte
+}
+
+func newTailBuffer(n int64) *tailBuffer {
+	return &tailBuffer{n: n}
+}
+
+func (t *tailBuffer) Write(s string) {
+	t.buf = append(t.buf, s...)
+	if int64(len(t.buf)) > t.n {
+		t.buf = t.buf[int64(len(t.buf))-t.n:]
+	}
+}
+
+func (t *tailBuffer) String() string {
+	return string(t.buf)
+}
+
+// fetchProgressiveText issues a single progressiveText?start=offset
+// request and returns the new text, the new total size (X-Text-Size),
+// and whether more data is available (X-More-Data).
+func (jc *JenkinsClient) fetchProgressiveText(ctx context.Context, path string, offset int64) (text string, size int64, moreData bool, err error) {
+	data, headers, err := jc.doWithHeaders(ctx, "GET", path, map[string]string{"start": strconv.FormatInt(offset, 10)}, nil)
+	if err != nil {
+		return "", offset, false, fmt.Errorf("progressive text fetch: %w", err)
+	}
+
+	sizeStr := headers.Get("X-Text-Size")
+	newSize := offset
+	if sizeStr != "" {
+		if n, err := strconv.ParseInt(sizeStr, 10, 64); err == nil {
+			newSize = n
+		}
+	}
+
+	return string(data), newSize, headers.Get("X-More-Data") == "true", nil
+}
+
+// reportProgress sends one "notifications/progress" message to the MCP
+// client for a chunk of streamed log text, when the caller included a
+// progress token in the tool call (per the MCP progress spec).
+func reportProgress(ctx context.Context, req mcp.CallToolRequest, chunk int, text string) {
+	token := req.Params.Meta
+	if token == nil || token.ProgressToken == nil {
+		return
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return
+	}
+	_ = srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+		"progressToken": token.ProgressToken,
+		"progress":      chunk,
+		"message":       text,
+	})
+}
+
+func grepLines(text string, re *regexp.Regexp) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	for _, l := range lines {
+		if re.MatchString(l) {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func intArg(args map[string]any, key string) (int, error) {
+	val, ok := args[key]
+	if !ok {
+		return 0, fmt.Errorf("missing %s", key)
+	}
+	switch v := val.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s string", key)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("invalid %s type", key)
+	}
+}